@@ -0,0 +1,37 @@
+package graphql
+
+// Automatic Persisted Queries (APQ), per Apollo's protocol: a request can
+// send just a query's SHA-256 hash instead of its full text; if the server
+// hasn't seen that hash before, it replies with a PersistedQueryNotFound
+// error and the client resends the full query alongside the hash so the
+// server can register it for next time.
+// https://www.apollographql.com/docs/apollo-server/performance/apq/
+
+// apqNotFoundMessage is the error message a server returns for a persisted
+// query hash it doesn't recognize yet.
+const apqNotFoundMessage = "PersistedQueryNotFound"
+
+// apqExtensions is the "extensions" object an APQ request body carries.
+type apqExtensions struct {
+	PersistedQuery apqPersistedQuery `json:"persistedQuery"`
+}
+
+type apqPersistedQuery struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// isPersistedQueryNotFound reports whether err is a GraphQL errors response
+// containing a PersistedQueryNotFound message.
+func isPersistedQueryNotFound(err error) bool {
+	errs, ok := err.(errors)
+	if !ok {
+		return false
+	}
+	for _, e := range errs {
+		if e.Message == apqNotFoundMessage {
+			return true
+		}
+	}
+	return false
+}
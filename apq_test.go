@@ -0,0 +1,73 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type zzApqQuery struct {
+	Hero *string `graphql:"hero"`
+}
+
+// TestClientPersistedQueriesSendsHashThenRetriesWithFullQuery drives a full
+// APQ round trip against a real HTTP server: the first request must carry
+// only the hash, a PersistedQueryNotFound response must trigger exactly one
+// retry, and that retry must carry both the full query and the hash.
+func TestClientPersistedQueriesSendsHashThenRetriesWithFullQuery(t *testing.T) {
+	var requests []struct {
+		Query      string                 `json:"query"`
+		Extensions map[string]interface{} `json:"extensions"`
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Query      string                 `json:"query"`
+			Extensions map[string]interface{} `json:"extensions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Fatalf("decoding request body: %v", err)
+		}
+		requests = append(requests, in)
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(requests) == 1 {
+			if in.Query != "" {
+				t.Errorf("expected the first request to omit the query, got %q", in.Query)
+			}
+			json.NewEncoder(w).Encode(graphQLResponse{
+				Errors: errors{{Message: apqNotFoundMessage}},
+			})
+			return
+		}
+
+		if in.Query == "" {
+			t.Error("expected the retry to carry the full query")
+		}
+		data := json.RawMessage(`{"hero":"Luke"}`)
+		json.NewEncoder(w).Encode(graphQLResponse{Data: &data})
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, nil).EnablePersistedQueries()
+
+	var q zzApqQuery
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if q.Hero == nil || *q.Hero != "Luke" {
+		t.Fatalf("expected hero %q, got %v", "Luke", q.Hero)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected exactly 2 requests (hash-only then retry), got %d", len(requests))
+	}
+
+	first := requests[0].Extensions["persistedQuery"].(map[string]interface{})
+	second := requests[1].Extensions["persistedQuery"].(map[string]interface{})
+	if first["sha256Hash"] != second["sha256Hash"] {
+		t.Fatalf("expected both requests to carry the same hash, got %v and %v", first, second)
+	}
+}
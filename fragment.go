@@ -0,0 +1,123 @@
+package graphql
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// FragmentDef is a named, reusable GraphQL selection set created by Fragment.
+// Reference it from another query/mutation/subscription struct with a
+// placeholder field tagged graphql:"...Name", and it will be appended as a
+// "fragment Name on Type { ... }" definition after the operation body,
+// exactly once per request no matter how many structs spread it.
+type FragmentDef struct {
+	name      string
+	onType    string
+	selection string
+	goType    reflect.Type
+}
+
+var (
+	fragmentsMu sync.RWMutex
+	fragments   = map[string]FragmentDef{}
+
+	// fragmentGeneration increments every time Fragment registers or
+	// redefines a fragment. queryAndHash folds its value into the query
+	// cache key so that redefining a fragment invalidates any cached query
+	// whose text was built against the old definition.
+	fragmentGeneration int64
+)
+
+// Fragment declares a named fragment on top of v's selection set, the same
+// way writeQuery derives one for a query/mutation struct, and registers it
+// under name so it can be referenced elsewhere via a placeholder field
+// tagged graphql:"...name". Re-declaring the same name overwrites it.
+func Fragment(name, onType string, v interface{}) FragmentDef {
+	t := reflect.TypeOf(v)
+	var buf bytes.Buffer
+	writeQuery(&buf, t, false)
+
+	def := FragmentDef{
+		name:      name,
+		onType:    onType,
+		selection: buf.String(),
+		goType:    t,
+	}
+
+	fragmentsMu.Lock()
+	fragments[name] = def
+	fragmentsMu.Unlock()
+	atomic.AddInt64(&fragmentGeneration, 1)
+
+	return def
+}
+
+// String renders the fragment's own "fragment Name on Type { ... }" definition.
+func (f FragmentDef) String() string {
+	return fmt.Sprintf("fragment %s on %s%s", f.name, f.onType, f.selection)
+}
+
+// fragmentSpreadName returns the referenced fragment's name if tag is a
+// fragment spread ("...Name"), as opposed to an inline fragment ("... on Type").
+func fragmentSpreadName(tag string) (string, bool) {
+	if !strings.HasPrefix(tag, "...") || strings.HasPrefix(tag, "... on ") {
+		return "", false
+	}
+	return strings.TrimPrefix(tag, "..."), true
+}
+
+// collectFragmentDefinitions returns the "fragment Name on Type { ... }"
+// definitions for every fragment spread transitively reachable from v,
+// each appearing once, in first-seen order.
+func collectFragmentDefinitions(v interface{}) string {
+	seen := make(map[string]bool)
+	var order []string
+	collectFragments(reflect.TypeOf(v), seen, &order)
+
+	var buf strings.Builder
+	for _, name := range order {
+		fragmentsMu.RLock()
+		def, ok := fragments[name]
+		fragmentsMu.RUnlock()
+		if ok {
+			buf.WriteString(def.String())
+		}
+	}
+	return buf.String()
+}
+
+func collectFragments(t reflect.Type, seen map[string]bool, order *[]string) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		collectFragments(t.Elem(), seen, order)
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag, ok := f.Tag.Lookup("graphql")
+			if ok {
+				if name, isSpread := fragmentSpreadName(tag); isSpread {
+					if !seen[name] {
+						seen[name] = true
+						*order = append(*order, name)
+
+						fragmentsMu.RLock()
+						def, known := fragments[name]
+						fragmentsMu.RUnlock()
+						if known {
+							collectFragments(def.goType, seen, order)
+						}
+					}
+					continue
+				}
+			}
+			collectFragments(f.Type, seen, order)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package graphql
+
+import "testing"
+
+type zzUserFields struct {
+	ID   int
+	Name string
+}
+
+type zzAuthor struct {
+	User zzUserFields `graphql:"...ZZUserFields"`
+}
+
+type zzPost struct {
+	Author zzAuthor
+}
+
+type zzCreatePost struct {
+	Post zzPost `graphql:"createPost(input: $input)"`
+}
+
+type zzCreateTwoPosts struct {
+	First  zzPost `graphql:"first: createPost(input: $input)"`
+	Second zzPost `graphql:"second: createPost(input: $input)"`
+}
+
+func TestFragmentSpreadOmitsItsOwnSelectionSet(t *testing.T) {
+	Fragment("ZZUserFields", "User", zzUserFields{})
+
+	out := query(&zzCreatePost{})
+	want := `{createPost(input: $input){Author{...ZZUserFields}}}`
+	if out != want {
+		t.Fatalf("got %q want %q", out, want)
+	}
+}
+
+func TestConstructMutationAppendsFragmentDefinitionOnce(t *testing.T) {
+	Fragment("ZZUserFields", "User", zzUserFields{})
+
+	out, err := constructMutation(&zzCreateTwoPosts{}, map[string]interface{}{"input": Int(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `mutation ($input:Int!){first: createPost(input: $input){Author{...ZZUserFields}},second: createPost(input: $input){Author{...ZZUserFields}}}fragment ZZUserFields on User{ID,Name}`
+	if out != want {
+		t.Fatalf("got %q want %q", out, want)
+	}
+}
+
+func TestFragmentSpreadNameDistinguishesFromInlineFragment(t *testing.T) {
+	if name, ok := fragmentSpreadName("...ZZUserFields"); !ok || name != "ZZUserFields" {
+		t.Fatalf("got (%q, %v)", name, ok)
+	}
+	if _, ok := fragmentSpreadName("... on User"); ok {
+		t.Fatal("expected an inline fragment tag to not be treated as a spread")
+	}
+}
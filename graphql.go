@@ -0,0 +1,175 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/hasura/go-graphql-client/internal/jsonutil"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// Client is a GraphQL client.
+type Client struct {
+	url              string // GraphQL server URL.
+	httpClient       *http.Client
+	persistedQueries bool
+}
+
+// NewClient creates a GraphQL client targeting the specified GraphQL server URL.
+// If httpClient is nil, then http.DefaultClient is used.
+func NewClient(url string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		url:        url,
+		httpClient: httpClient,
+	}
+}
+
+// EnablePersistedQueries turns on Apollo's Automatic Persisted Queries
+// protocol for this client: each request first sends only the query's
+// SHA-256 hash as a "persistedQuery" extension, omitting the query body; if
+// the server reports the hash as unknown (a PersistedQueryNotFound error),
+// the full query is resent alongside the hash so the server can register it
+// for next time.
+func (c *Client) EnablePersistedQueries() *Client {
+	c.persistedQueries = true
+	return c
+}
+
+// Query executes a single GraphQL query request,
+// with a query derived from q, populating the response into it.
+// q should be a pointer to struct that corresponds to the GraphQL schema.
+func (c *Client) Query(ctx context.Context, q interface{}, variables map[string]interface{}, options ...Option) error {
+	return c.do(ctx, queryOperation, q, variables, options...)
+}
+
+// Mutate executes a single GraphQL mutation request,
+// with a mutation derived from m, populating the response into it.
+// m should be a pointer to struct that corresponds to the GraphQL schema.
+func (c *Client) Mutate(ctx context.Context, m interface{}, variables map[string]interface{}, options ...Option) error {
+	return c.do(ctx, mutationOperation, m, variables, options...)
+}
+
+// do executes a single GraphQL operation and unmarshal json.
+func (c *Client) do(ctx context.Context, op operationType, v interface{}, variables map[string]interface{}, options ...Option) error {
+	query, hash, err := queryAndHash(op, v, variables, options)
+	if err != nil {
+		return err
+	}
+	vars := flattenVariables(variables)
+
+	if c.persistedQueries {
+		out, err := c.post(ctx, "", hash, vars)
+		if err != nil {
+			return err
+		}
+		if isPersistedQueryNotFound(out.Errors) {
+			out, err = c.post(ctx, query, hash, vars)
+			if err != nil {
+				return err
+			}
+		}
+		return unmarshalResponse(out, v)
+	}
+
+	out, err := c.post(ctx, query, [sha256.Size]byte{}, vars)
+	if err != nil {
+		return err
+	}
+	return unmarshalResponse(out, v)
+}
+
+// graphQLResponse is the shape of a single GraphQL HTTP response body.
+type graphQLResponse struct {
+	Data   *json.RawMessage
+	Errors errors
+}
+
+// post sends a single GraphQL HTTP request. hash is included as an
+// Automatic Persisted Queries extension unless it's the zero value; query is
+// omitted from the body when empty, per APQ's hash-only first attempt.
+func (c *Client) post(ctx context.Context, query string, hash [sha256.Size]byte, variables map[string]interface{}) (*graphQLResponse, error) {
+	in := struct {
+		Query      string                 `json:"query,omitempty"`
+		Variables  map[string]interface{} `json:"variables,omitempty"`
+		Extensions *apqExtensions         `json:"extensions,omitempty"`
+	}{
+		Query:     query,
+		Variables: variables,
+	}
+	if hash != ([sha256.Size]byte{}) {
+		in.Extensions = &apqExtensions{PersistedQuery: apqPersistedQuery{Version: 1, Sha256Hash: hex.EncodeToString(hash[:])}}
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(in); err != nil {
+		return nil, err
+	}
+	resp, err := ctxhttp.Post(ctx, c.httpClient, c.url, "application/json", &buf)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("non-200 OK status code: %v body: %q", resp.Status, body)
+	}
+
+	var out graphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// unmarshalResponse decodes a successful response's data into v, or returns
+// its errors.
+func unmarshalResponse(out *graphQLResponse, v interface{}) error {
+	if out.Data != nil {
+		if err := jsonutil.UnmarshalGraphQL(*out.Data, v); err != nil {
+			return err
+		}
+	}
+	if len(out.Errors) > 0 {
+		return out.Errors
+	}
+	return nil
+}
+
+// errors represents the "errors" array in a response from a GraphQL server.
+// If returned via error interface, the slice is expected to contain at least 1 element.
+//
+// Specification: https://facebook.github.io/graphql/#sec-Errors.
+type errors []struct {
+	Message   string
+	Locations []struct {
+		Line   int
+		Column int
+	}
+}
+
+// Error implements error interface.
+func (e errors) Error() string {
+	b := strings.Builder{}
+	for _, err := range e {
+		b.WriteString(fmt.Sprintf("Message: %s, Locations: %+v", err.Message, err.Locations))
+	}
+	return b.String()
+}
+
+type operationType uint8
+
+const (
+	queryOperation operationType = iota
+	mutationOperation
+	subscriptionOperation
+)
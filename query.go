@@ -40,7 +40,26 @@ func constructOptions(options []Option) (*constructOptionsOutput, error) {
 	return output, nil
 }
 
+// constructQuery, constructMutation and constructSubscription return just
+// the query string of queryAndHash (which Client and SubscriptionClient call
+// directly): the result is memoized, falling back to buildQuery/
+// buildMutation/buildSubscription on a cache miss.
 func constructQuery(v interface{}, variables map[string]interface{}, options ...Option) (string, error) {
+	query, _, err := queryAndHash(queryOperation, v, variables, options)
+	return query, err
+}
+
+func constructMutation(v interface{}, variables map[string]interface{}, options ...Option) (string, error) {
+	query, _, err := queryAndHash(mutationOperation, v, variables, options)
+	return query, err
+}
+
+func constructSubscription(v interface{}, variables map[string]interface{}, options ...Option) (string, error) {
+	query, _, err := queryAndHash(subscriptionOperation, v, variables, options)
+	return query, err
+}
+
+func buildQuery(v interface{}, variables map[string]interface{}, options ...Option) (string, error) {
 	query := query(v)
 
 	optionsOutput, err := constructOptions(options)
@@ -48,52 +67,62 @@ func constructQuery(v interface{}, variables map[string]interface{}, options ...
 		return "", err
 	}
 
+	fragments := collectFragmentDefinitions(v)
+
 	if len(variables) > 0 {
-		return fmt.Sprintf("query %s(%s)%s%s", optionsOutput.operationName, queryArguments(variables), optionsOutput.OperationDirectivesString(), query), nil
+		return fmt.Sprintf("query %s(%s)%s%s%s", optionsOutput.operationName, queryArguments(variables), optionsOutput.OperationDirectivesString(), query, fragments), nil
 	}
 
 	if optionsOutput.operationName == "" && len(optionsOutput.operationDirectives) == 0 {
-		return query, nil
+		return query + fragments, nil
 	}
 
-	return fmt.Sprintf("query %s%s%s", optionsOutput.operationName, optionsOutput.OperationDirectivesString(), query), nil
+	return fmt.Sprintf("query %s%s%s%s", optionsOutput.operationName, optionsOutput.OperationDirectivesString(), query, fragments), nil
 }
 
-func constructMutation(v interface{}, variables map[string]interface{}, options ...Option) (string, error) {
+func buildMutation(v interface{}, variables map[string]interface{}, options ...Option) (string, error) {
 	query := query(v)
 	optionsOutput, err := constructOptions(options)
 	if err != nil {
 		return "", err
 	}
+
+	fragments := collectFragmentDefinitions(v)
+
 	if len(variables) > 0 {
-		return fmt.Sprintf("mutation %s(%s)%s%s", optionsOutput.operationName, queryArguments(variables), optionsOutput.OperationDirectivesString(), query), nil
+		return fmt.Sprintf("mutation %s(%s)%s%s%s", optionsOutput.operationName, queryArguments(variables), optionsOutput.OperationDirectivesString(), query, fragments), nil
 	}
 
 	if optionsOutput.operationName == "" && len(optionsOutput.operationDirectives) == 0 {
-		return "mutation" + query, nil
+		return "mutation" + query + fragments, nil
 	}
 
-	return fmt.Sprintf("mutation %s%s%s", optionsOutput.operationName, optionsOutput.OperationDirectivesString(), query), nil
+	return fmt.Sprintf("mutation %s%s%s%s", optionsOutput.operationName, optionsOutput.OperationDirectivesString(), query, fragments), nil
 }
 
-func constructSubscription(v interface{}, variables map[string]interface{}, options ...Option) (string, error) {
+func buildSubscription(v interface{}, variables map[string]interface{}, options ...Option) (string, error) {
 	query := query(v)
 	optionsOutput, err := constructOptions(options)
 	if err != nil {
 		return "", err
 	}
+
+	fragments := collectFragmentDefinitions(v)
+
 	if len(variables) > 0 {
-		return fmt.Sprintf("subscription %s(%s)%s%s", optionsOutput.operationName, queryArguments(variables), optionsOutput.OperationDirectivesString(), query), nil
+		return fmt.Sprintf("subscription %s(%s)%s%s%s", optionsOutput.operationName, queryArguments(variables), optionsOutput.OperationDirectivesString(), query, fragments), nil
 	}
 	if optionsOutput.operationName == "" && len(optionsOutput.operationDirectives) == 0 {
-		return "subscription" + query, nil
+		return "subscription" + query + fragments, nil
 	}
-	return fmt.Sprintf("subscription %s%s%s", optionsOutput.operationName, optionsOutput.OperationDirectivesString(), query), nil
+	return fmt.Sprintf("subscription %s%s%s%s", optionsOutput.operationName, optionsOutput.OperationDirectivesString(), query, fragments), nil
 }
 
 // queryArguments constructs a minified arguments string for variables.
 //
 // E.g., map[string]interface{}{"a": Int(123), "b": NewBoolean(true)} -> "$a:Int!$b:Boolean".
+// A variable built with WithDefault contributes its default too, e.g.
+// map[string]interface{}{"a": WithDefault(Int(123), 5)} -> "$a:Int!=5".
 func queryArguments(variables map[string]interface{}) string {
 	// Sort keys in order to produce deterministic output for testing purposes.
 	// TODO: If tests can be made to work with non-deterministic output, then no need to sort.
@@ -108,7 +137,12 @@ func queryArguments(variables map[string]interface{}) string {
 		io.WriteString(&buf, "$")
 		io.WriteString(&buf, k)
 		io.WriteString(&buf, ":")
-		writeArgumentType(&buf, reflect.TypeOf(variables[k]), true)
+		if v, ok := variables[k].(Variable); ok {
+			writeArgumentType(&buf, reflect.TypeOf(v.Value), true)
+			_ = writeArgumentDefault(&buf, v)
+		} else {
+			writeArgumentType(&buf, reflect.TypeOf(variables[k]), true)
+		}
 		// Don't insert a comma here.
 		// Commas in GraphQL are insignificant, and we want minified output.
 		// See https://facebook.github.io/graphql/October2016/#sec-Insignificant-Commas.
@@ -126,12 +160,23 @@ func writeArgumentType(w io.Writer, t reflect.Type, value bool) {
 		return
 	}
 
+	if name, ok := lookupRegisteredTypeName(t); ok {
+		io.WriteString(w, name)
+		if value {
+			io.WriteString(w, "!")
+		}
+		return
+	}
+
 	switch t.Kind() {
 	case reflect.Slice, reflect.Array:
 		// List. E.g., "[Int]".
 		io.WriteString(w, "[")
 		writeArgumentType(w, t.Elem(), true)
 		io.WriteString(w, "]")
+	case reflect.Struct:
+		// Input object. E.g., "CreatePostInput".
+		io.WriteString(w, inputObjectName(t))
 	default:
 		// Named type. E.g., "Int".
 		name := t.Name()
@@ -159,6 +204,20 @@ func query(v interface{}) string {
 
 // writeQuery writes a minified query for t to w.
 // If inline is true, the struct fields of t are inlined into parent struct.
+//
+// A field's graphql tag is written verbatim, so it may carry directive
+// syntax, e.g. graphql:"name @include(if: $withName)", or mark the field
+// as a GraphQL inline fragment by starting with "... on TypeName", e.g. a
+// field of type Issue tagged graphql:"... on Issue" writes "... on Issue{...}"
+// without ever emitting the Go field's own name. Variables referenced in
+// directives must still be declared via the variables map passed to
+// constructQuery/constructMutation/constructSubscription.
+//
+// A tag of the form "...Name" (without " on ") is a fragment spread: it
+// writes "...Name" and nothing else, leaving the referenced field's own
+// selection set to come from the fragment definition registered with
+// Fragment. constructQuery/constructMutation/constructSubscription collect
+// and append those definitions, deduplicated by name.
 func writeQuery(w io.Writer, t reflect.Type, inline bool) {
 	switch t.Kind() {
 	case reflect.Ptr, reflect.Slice:
@@ -185,6 +244,11 @@ func writeQuery(w io.Writer, t reflect.Type, inline bool) {
 					io.WriteString(w, f.Name)
 				}
 			}
+			// A fragment spread ("...Name") has no selection set of its own
+			// here; its fields live in the separate fragment definition.
+			if _, isSpread := fragmentSpreadName(value); ok && isSpread {
+				continue
+			}
 			writeQuery(w, f.Type, inlineField)
 		}
 		if !inline {
@@ -0,0 +1,135 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// queryCacheKey identifies everything about a constructQuery/Mutation/
+// Subscription call that determines its rendered output: the operation
+// kind, the Go type of v, a signature of the variables and options, and the
+// fragment registry's generation, so the same shape of operation reuses a
+// previously-rendered query no matter what concrete values are passed for
+// it, while a Fragment redefinition (which changes what any fragment spread
+// in v expands to) still invalidates the entries that depend on it.
+type queryCacheKey struct {
+	kind        operationType
+	t           reflect.Type
+	varsSig     string
+	optsSig     string
+	fragmentGen int64
+}
+
+type queryCacheEntry struct {
+	query string
+	hash  [sha256.Size]byte
+}
+
+// maxQueryCacheEntries bounds the cache's size. optsSig encodes Option
+// values (including an OperationName) literally, so this cache assumes
+// callers reuse a small, stable set of operation names/options per distinct
+// operation shape; a caller that mints a new OperationName per call (e.g. a
+// trace or request id) would otherwise grow the cache without bound for the
+// life of the process. Once the bound is hit, the whole cache is cleared
+// and rebuilt from scratch rather than attempting partial eviction.
+const maxQueryCacheEntries = 1000
+
+var (
+	queryCacheMu sync.RWMutex
+	queryCache   = map[queryCacheKey]queryCacheEntry{}
+)
+
+// queryAndHash returns the minified query string for op and its SHA-256
+// hash, the latter for use with Automatic Persisted Queries. Results are
+// memoized per queryCacheKey, so repeated calls for the same shape of
+// operation skip writeQuery's and queryArguments' reflection walk entirely.
+func queryAndHash(op operationType, v interface{}, variables map[string]interface{}, options []Option) (string, [sha256.Size]byte, error) {
+	key := queryCacheKey{
+		kind:        op,
+		t:           reflect.TypeOf(v),
+		varsSig:     querySignature(variables),
+		optsSig:     optionsSignature(options),
+		fragmentGen: atomic.LoadInt64(&fragmentGeneration),
+	}
+
+	queryCacheMu.RLock()
+	entry, ok := queryCache[key]
+	queryCacheMu.RUnlock()
+	if ok {
+		return entry.query, entry.hash, nil
+	}
+
+	var query string
+	var err error
+	switch op {
+	case queryOperation:
+		query, err = buildQuery(v, variables, options...)
+	case mutationOperation:
+		query, err = buildMutation(v, variables, options...)
+	case subscriptionOperation:
+		query, err = buildSubscription(v, variables, options...)
+	}
+	if err != nil {
+		return "", [sha256.Size]byte{}, err
+	}
+
+	entry = queryCacheEntry{query: query, hash: sha256.Sum256([]byte(query))}
+
+	queryCacheMu.Lock()
+	if len(queryCache) >= maxQueryCacheEntries {
+		queryCache = map[queryCacheKey]queryCacheEntry{}
+	}
+	queryCache[key] = entry
+	queryCacheMu.Unlock()
+
+	return entry.query, entry.hash, nil
+}
+
+// querySignature returns a deterministic string describing everything about
+// variables that affects the rendered query text: each variable's name, its
+// value's Go type, and, for a WithDefault variable, its default's JSON
+// encoding.
+func querySignature(variables map[string]interface{}) string {
+	keys := make([]string, 0, len(variables))
+	for k := range variables {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteString(":")
+		if vr, ok := variables[k].(Variable); ok {
+			b.WriteString(reflect.TypeOf(vr.Value).String())
+			if vr.hasDefault {
+				if j, err := json.Marshal(vr.Default); err == nil {
+					b.Write(j)
+				}
+			}
+		} else {
+			b.WriteString(reflect.TypeOf(variables[k]).String())
+		}
+		b.WriteString(";")
+	}
+	return b.String()
+}
+
+// optionsSignature returns a deterministic string describing options in the
+// order given, since e.g. operation directive order affects the rendered
+// query.
+func optionsSignature(options []Option) string {
+	var b strings.Builder
+	for _, o := range options {
+		b.WriteString(string(o.Type()))
+		b.WriteString(":")
+		b.WriteString(o.String())
+		b.WriteString(";")
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"fmt"
+	"testing"
+)
+
+type zzCacheQuery struct {
+	Hero *string `graphql:"hero(id: $id)"`
+}
+
+func TestQueryCacheReusesIdenticalShape(t *testing.T) {
+	before := len(queryCache)
+	q1, _ := constructQuery(&zzCacheQuery{}, map[string]interface{}{"id": Int(1)})
+	afterFirst := len(queryCache)
+	q2, _ := constructQuery(&zzCacheQuery{}, map[string]interface{}{"id": Int(2)})
+	afterSecond := len(queryCache)
+
+	if q1 != q2 {
+		t.Fatalf("expected the rendered query to depend on the variable's type, not its value: got %q vs %q", q1, q2)
+	}
+	if afterFirst != before+1 {
+		t.Fatalf("expected exactly one new cache entry, had %d now %d", before, afterFirst)
+	}
+	if afterSecond != afterFirst {
+		t.Fatalf("expected a cache hit to add no new entry, had %d now %d", afterFirst, afterSecond)
+	}
+}
+
+func TestQueryCacheDistinguishesVariableTypes(t *testing.T) {
+	outInt, err := constructQuery(&zzCacheQuery{}, map[string]interface{}{"id": Int(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outString, err := constructQuery(&zzCacheQuery{}, map[string]interface{}{"id": String("x")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outInt == outString {
+		t.Fatalf("expected different variable types to produce different cache entries/output, got %q for both", outInt)
+	}
+}
+
+type zzCacheFragUserA struct {
+	ID int
+}
+
+type zzCacheFragUserB struct {
+	ID   int
+	Name string
+}
+
+type zzCacheFragAuthor struct {
+	User zzCacheFragUserA `graphql:"...ZZCacheFragUser"`
+}
+
+type zzCacheFragQuery struct {
+	Author zzCacheFragAuthor
+}
+
+func TestQueryCacheInvalidatedByFragmentRedefinition(t *testing.T) {
+	Fragment("ZZCacheFragUser", "User", zzCacheFragUserA{})
+	out1, err := constructQuery(&zzCacheFragQuery{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want1 := `{Author{...ZZCacheFragUser}}fragment ZZCacheFragUser on User{ID}`
+	if out1 != want1 {
+		t.Fatalf("got %q want %q", out1, want1)
+	}
+
+	// Redefining the fragment under the same name must invalidate any
+	// already-cached query that spreads it, per Fragment's own "overwrites
+	// it" doc comment.
+	Fragment("ZZCacheFragUser", "User", zzCacheFragUserB{})
+	out2, err := constructQuery(&zzCacheFragQuery{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want2 := `{Author{...ZZCacheFragUser}}fragment ZZCacheFragUser on User{ID,Name}`
+	if out2 != want2 {
+		t.Fatalf("expected redefined fragment's fields in a fresh build, got %q want %q", out2, want2)
+	}
+}
+
+func TestQueryCacheCapEvictsRatherThanGrowingUnbounded(t *testing.T) {
+	for i := 0; i < maxQueryCacheEntries+10; i++ {
+		vars := map[string]interface{}{fmt.Sprintf("v%d", i): Int(i)}
+		if _, err := constructQuery(&zzCacheQuery{}, vars); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queryCacheMu.RLock()
+	n := len(queryCache)
+	queryCacheMu.RUnlock()
+
+	if n > maxQueryCacheEntries {
+		t.Fatalf("expected cache size bounded by %d entries, got %d", maxQueryCacheEntries, n)
+	}
+}
@@ -0,0 +1,51 @@
+package graphql
+
+import "testing"
+
+type zzIssue struct {
+	Title string
+}
+
+type zzPullRequest struct {
+	Title    string
+	Reviewed bool
+}
+
+type zzTimelineItem struct {
+	Issue       zzIssue       `graphql:"... on Issue"`
+	PullRequest zzPullRequest `graphql:"... on PullRequest"`
+}
+
+type zzDirectiveQuery struct {
+	Name string `graphql:"name @include(if: $withName)"`
+}
+
+func TestWriteQueryFieldDirectiveTagIsEmittedVerbatim(t *testing.T) {
+	out, err := constructQuery(&zzDirectiveQuery{}, map[string]interface{}{"withName": Boolean(true)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `query ($withName:Boolean!){name @include(if: $withName)}`
+	if out != want {
+		t.Fatalf("got %q want %q", out, want)
+	}
+}
+
+func TestWriteQueryInlineFragmentExpandsSelectionSet(t *testing.T) {
+	out := query(&zzTimelineItem{})
+	want := `{... on Issue{Title},... on PullRequest{Title,Reviewed}}`
+	if out != want {
+		t.Fatalf("got %q want %q", out, want)
+	}
+}
+
+func TestOperationDirectiveRendersAfterArguments(t *testing.T) {
+	out, err := constructQuery(&zzDirectiveQuery{}, map[string]interface{}{"withName": Boolean(true)}, OperationDirective("@live"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `query ($withName:Boolean!) @live {name @include(if: $withName)}`
+	if out != want {
+		t.Fatalf("got %q want %q", out, want)
+	}
+}
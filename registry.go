@@ -0,0 +1,70 @@
+package graphql
+
+import (
+	"reflect"
+	"sync"
+)
+
+// typeRegistry maps Go types to the GraphQL type name writeArgumentType should
+// emit for them. Once writeArgumentType has unwrapped any pointer, it's
+// consulted before the rest of its built-in rules, so callers can teach the package about scalars (time.Time -> DateTime,
+// uuid.UUID -> UUID, json.RawMessage -> JSON, ...) and input objects without
+// relying on Go type name conventions.
+var (
+	typeRegistryMu sync.RWMutex
+	typeRegistry   = map[reflect.Type]string{}
+)
+
+// RegisterScalar maps goType to gqlName, so that variables of goType are
+// declared with gqlName in query/mutation argument lists. This replaces the
+// historical "every string is an ID" assumption for types the caller knows
+// the server represents as a custom scalar, e.g.:
+//
+//	graphql.RegisterScalar(reflect.TypeOf(time.Time{}), "DateTime")
+//	graphql.RegisterScalar(reflect.TypeOf(uuid.UUID{}), "UUID")
+//	graphql.RegisterScalar(reflect.TypeOf(json.RawMessage{}), "JSON")
+func RegisterScalar(goType reflect.Type, gqlName string) {
+	typeRegistryMu.Lock()
+	defer typeRegistryMu.Unlock()
+	typeRegistry[goType] = gqlName
+}
+
+// RegisterInputObject maps goType, a Go struct type used as a query variable,
+// to gqlName, the GraphQL input object type it should be declared as. A
+// variable holding a pointer to goType is declared as gqlName without "!";
+// registering a pointer type itself never matches. It's equivalent to RegisterScalar, kept as a distinct name so
+// call sites can document intent.
+func RegisterInputObject(goType reflect.Type, gqlName string) {
+	RegisterScalar(goType, gqlName)
+}
+
+// lookupRegisteredTypeName returns the GraphQL type name registered for t, if any.
+func lookupRegisteredTypeName(t reflect.Type) (string, bool) {
+	typeRegistryMu.RLock()
+	defer typeRegistryMu.RUnlock()
+	name, ok := typeRegistry[t]
+	return name, ok
+}
+
+// inputObjectName derives the GraphQL input object name for a struct type
+// that wasn't found in the registry: the value of a `graphql:"..."` tag on an
+// unexported "_" marker field, if present, otherwise the Go type's own name.
+//
+//	type CreatePostInput struct {
+//		_     struct{} `graphql:"CreatePostInput"`
+//		Title string
+//		Body  string
+//	}
+func inputObjectName(t reflect.Type) string {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Name != "_" {
+			continue
+		}
+		if tag, ok := f.Tag.Lookup("graphql"); ok && tag != "" {
+			return tag
+		}
+		break
+	}
+	return t.Name()
+}
@@ -0,0 +1,45 @@
+package graphql
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type zzRegCustomScalar struct{}
+
+type zzRegInput struct {
+	Title string
+}
+
+type zzRegNamedInput struct {
+	_     struct{} `graphql:"CreatePostInput"`
+	Title string
+}
+
+func TestRegisterScalarIsConsultedBeforeBuiltinRules(t *testing.T) {
+	typ := reflect.TypeOf(zzRegCustomScalar{})
+	RegisterScalar(typ, "ZZCustomScalar")
+
+	var buf bytes.Buffer
+	writeArgumentType(&buf, typ, true)
+	if got, want := buf.String(), "ZZCustomScalar!"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestInputObjectNameFallsBackToGoTypeName(t *testing.T) {
+	var buf bytes.Buffer
+	writeArgumentType(&buf, reflect.TypeOf(zzRegInput{}), true)
+	if got, want := buf.String(), "zzRegInput!"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestInputObjectNameHonorsMarkerFieldTag(t *testing.T) {
+	var buf bytes.Buffer
+	writeArgumentType(&buf, reflect.TypeOf(zzRegNamedInput{}), true)
+	if got, want := buf.String(), "CreatePostInput!"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
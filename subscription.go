@@ -0,0 +1,770 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hasura/go-graphql-client/internal/jsonutil"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// Subscription transports are pluggable: the client can speak either the legacy
+// Apollo subscriptions-transport-ws protocol (subprotocol "graphql-ws") or the
+// modern graphql-ws protocol (subprotocol "graphql-transport-ws").
+// See subscriptions_transport_ws.go and subscription_graphql_ws.go respectively.
+
+// OperationMessageType
+type OperationMessageType string
+
+const (
+	// Unknown operation type, for logging only
+	GQL_UNKNOWN OperationMessageType = "unknown"
+	// Internal status, for logging only
+	GQL_INTERNAL OperationMessageType = "internal"
+)
+
+type OperationMessage struct {
+	ID      string               `json:"id,omitempty"`
+	Type    OperationMessageType `json:"type"`
+	Payload json.RawMessage      `json:"payload,omitempty"`
+}
+
+func (om OperationMessage) String() string {
+	bs, _ := json.Marshal(om)
+
+	return string(bs)
+}
+
+// WebsocketConn abstracts WebSocket connection functions
+// ReadJSON and WriteJSON data of a frame from the WebSocket connection.
+// Close the WebSocket connection.
+type WebsocketConn interface {
+	ReadJSON(v interface{}) error
+	WriteJSON(v interface{}) error
+	Close() error
+	// SetReadLimit sets the maximum size in bytes for a message read from the peer. If a
+	// message exceeds the limit, the connection sends a close message to the peer
+	// and returns ErrReadLimit to the application.
+	SetReadLimit(limit int64)
+}
+
+type handlerFunc func(data *json.RawMessage, err error) error
+
+// subscriptionState tracks the bookkeeping the client needs for a single active
+// operation: what to (re)send on (re)connect, and where delivered data goes.
+type subscriptionState struct {
+	query          string
+	variables      map[string]interface{}
+	handler        func(data *json.RawMessage, err error)
+	resultType     reflect.Type
+	started        Boolean
+	hash           [sha256.Size]byte
+	persistedQuery bool
+	fullQuerySent  bool
+}
+
+// Subscription is a handle to a single subscription started via
+// SubscriptionClient.Subscribe. It lets the caller stop receiving data for
+// that operation without tearing down the whole client.
+type Subscription struct {
+	id     string
+	client *SubscriptionClient
+}
+
+// ID returns the identifier the server knows this subscription by.
+func (s *Subscription) ID() string {
+	return s.id
+}
+
+// Unsubscribe sends a "stop"/"complete" frame for this subscription and
+// removes it from the client.
+func (s *Subscription) Unsubscribe() error {
+	return s.client.Unsubscribe(s.id)
+}
+
+// protocol abstracts the wire-level differences between the subscription
+// transports the client supports. Exactly one protocol is active per
+// SubscriptionClient, chosen with WithProtocol.
+type protocol interface {
+	// subprotocol is the WebSocket subprotocol name sent during the handshake.
+	subprotocol() string
+	// connectionInit builds the first frame sent after the socket is open.
+	connectionInit(payload json.RawMessage) OperationMessage
+	// subscribe builds the frame that starts a new operation. extensions is
+	// non-nil when Automatic Persisted Queries is enabled; query is empty on
+	// the initial hash-only attempt and populated again on retry once the
+	// server reports the hash as unknown.
+	subscribe(id string, query string, variables map[string]interface{}, extensions map[string]interface{}) (OperationMessage, error)
+	// unsubscribe builds the frame that stops a running operation.
+	unsubscribe(id string) OperationMessage
+	// onMessage reacts to a single frame received from the server.
+	onMessage(sc *SubscriptionClient, msg OperationMessage)
+}
+
+// SubscriptionClient is a GraphQL subscription client.
+type SubscriptionClient struct {
+	url              string
+	conn             WebsocketConn
+	connectionParams map[string]interface{}
+	context          context.Context
+	subscriptions    map[string]*subscriptionState
+	cancel           context.CancelFunc
+	subscribersMu    sync.Mutex
+	timeout          time.Duration
+	ackTimeout       time.Duration
+	isRunning        int64
+	acknowledged     int64
+	readLimit        int64 // max size of response message. Default 10 MB
+	log              func(args ...interface{})
+	createConn       func(sc *SubscriptionClient) (WebsocketConn, error)
+	retryTimeout     time.Duration
+	minBackoff       time.Duration
+	maxBackoff       time.Duration
+	onConnected      func()
+	onDisconnected   func()
+	onError          func(sc *SubscriptionClient, err error) error
+	errorChan        chan error
+	disabledLogTypes []OperationMessageType
+	protocol         protocol
+	persistedQueries bool
+}
+
+// NewSubscriptionClient creates a subscription client. By default it speaks
+// the legacy subscriptions-transport-ws protocol ("graphql-ws" subprotocol);
+// use WithProtocol to opt into the newer graphql-transport-ws protocol.
+func NewSubscriptionClient(url string) *SubscriptionClient {
+	return &SubscriptionClient{
+		url:           url,
+		timeout:       time.Minute,
+		ackTimeout:    10 * time.Second,
+		readLimit:     10 * 1024 * 1024, // set default limit 10MB
+		subscriptions: make(map[string]*subscriptionState),
+		createConn:    newWebsocketConn,
+		retryTimeout:  time.Minute,
+		minBackoff:    time.Second,
+		maxBackoff:    30 * time.Second,
+		errorChan:     make(chan error),
+		protocol:      &subscriptionsTransportWS{},
+	}
+}
+
+// GetURL returns GraphQL server's URL
+func (sc *SubscriptionClient) GetURL() string {
+	return sc.url
+}
+
+// GetContext returns current context of subscription client
+func (sc *SubscriptionClient) GetContext() context.Context {
+	return sc.context
+}
+
+// GetTimeout returns write timeout of websocket client
+func (sc *SubscriptionClient) GetTimeout() time.Duration {
+	return sc.timeout
+}
+
+// WithWebSocket replaces customized websocket client constructor
+// In default, subscription client uses https://github.com/nhooyr/websocket
+func (sc *SubscriptionClient) WithWebSocket(fn func(sc *SubscriptionClient) (WebsocketConn, error)) *SubscriptionClient {
+	sc.createConn = fn
+	return sc
+}
+
+// WithProtocol selects the subscription transport protocol to speak.
+// Use ProtocolGraphQLTransportWS to talk the modern graphql-ws spec; the
+// default, ProtocolGraphQLWS, is the legacy Apollo protocol.
+func (sc *SubscriptionClient) WithProtocol(name string) *SubscriptionClient {
+	switch name {
+	case ProtocolGraphQLTransportWS:
+		sc.protocol = &graphqlWS{}
+	default:
+		sc.protocol = &subscriptionsTransportWS{}
+	}
+	return sc
+}
+
+// WithPersistedQueries enables Apollo's Automatic Persisted Queries protocol
+// for subsequent Subscribe calls: each subscribe frame first omits the query
+// body and sends only its SHA-256 hash as a "persistedQuery" extension; if
+// the server reports the hash as unknown, the full query is resent
+// alongside the hash so the server can register it. Matches Client.EnablePersistedQueries.
+func (sc *SubscriptionClient) WithPersistedQueries() *SubscriptionClient {
+	sc.persistedQueries = true
+	return sc
+}
+
+// WithConnectionParams updates connection params for sending to server through the
+// connection-init event. It's usually used for authentication handshake.
+func (sc *SubscriptionClient) WithConnectionParams(params map[string]interface{}) *SubscriptionClient {
+	sc.connectionParams = params
+	return sc
+}
+
+// WithTimeout updates write timeout of websocket client
+func (sc *SubscriptionClient) WithTimeout(timeout time.Duration) *SubscriptionClient {
+	sc.timeout = timeout
+	return sc
+}
+
+// WithAckTimeout updates how long the client waits for connection_ack after
+// connection_init before treating the handshake as failed and retrying.
+func (sc *SubscriptionClient) WithAckTimeout(timeout time.Duration) *SubscriptionClient {
+	sc.ackTimeout = timeout
+	return sc
+}
+
+// WithRetryTimeout updates the overall reconnecting deadline. When the websocket
+// server is down, the client retries connecting, backing off exponentially
+// between attempts, until this deadline elapses.
+func (sc *SubscriptionClient) WithRetryTimeout(timeout time.Duration) *SubscriptionClient {
+	sc.retryTimeout = timeout
+	return sc
+}
+
+// WithBackoff sets the exponential backoff bounds used between reconnect attempts.
+func (sc *SubscriptionClient) WithBackoff(min, max time.Duration) *SubscriptionClient {
+	sc.minBackoff = min
+	sc.maxBackoff = max
+	return sc
+}
+
+// WithLog sets loging function to print out received messages. By default, nothing is printed
+func (sc *SubscriptionClient) WithLog(logger func(args ...interface{})) *SubscriptionClient {
+	sc.log = logger
+	return sc
+}
+
+// WithoutLogTypes these operation types won't be printed
+func (sc *SubscriptionClient) WithoutLogTypes(types ...OperationMessageType) *SubscriptionClient {
+	sc.disabledLogTypes = types
+	return sc
+}
+
+// WithReadLimit set max size of response message
+func (sc *SubscriptionClient) WithReadLimit(limit int64) *SubscriptionClient {
+	sc.readLimit = limit
+	return sc
+}
+
+// OnError event is triggered when there is any connection error. This is bottom exception handler level
+// If this function is empty, or returns nil, the error is ignored
+// If returns error, the websocket connection will be terminated
+func (sc *SubscriptionClient) OnError(onError func(sc *SubscriptionClient, err error) error) *SubscriptionClient {
+	sc.onError = onError
+	return sc
+}
+
+// OnConnected event is triggered when the websocket connected to GraphQL server successfully
+func (sc *SubscriptionClient) OnConnected(fn func()) *SubscriptionClient {
+	sc.onConnected = fn
+	return sc
+}
+
+// OnDisconnected event is triggered when the websocket server was still down after retry timeout
+func (sc *SubscriptionClient) OnDisconnected(fn func()) *SubscriptionClient {
+	sc.onDisconnected = fn
+	return sc
+}
+
+func (sc *SubscriptionClient) setIsRunning(value Boolean) {
+	if value {
+		atomic.StoreInt64(&sc.isRunning, 1)
+	} else {
+		atomic.StoreInt64(&sc.isRunning, 0)
+	}
+}
+
+func (sc *SubscriptionClient) setAcknowledged(value Boolean) {
+	if value {
+		atomic.StoreInt64(&sc.acknowledged, 1)
+	} else {
+		atomic.StoreInt64(&sc.acknowledged, 0)
+	}
+}
+
+func (sc *SubscriptionClient) init() error {
+	now := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	sc.context = ctx
+	sc.cancel = cancel
+
+	backoff := sc.minBackoff
+	for {
+		err := sc.connect()
+		if err == nil {
+			return nil
+		}
+
+		if now.Add(sc.retryTimeout).Before(time.Now()) {
+			if sc.onDisconnected != nil {
+				sc.onDisconnected()
+			}
+			return err
+		}
+		sc.printLog(err.Error()+fmt.Sprintf(". retry in %s....", backoff), GQL_INTERNAL)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > sc.maxBackoff {
+			backoff = sc.maxBackoff
+		}
+	}
+}
+
+// connect dials the websocket (if needed), sends connection_init, and waits
+// for connection_ack before returning.
+func (sc *SubscriptionClient) connect() error {
+	if sc.conn == nil {
+		conn, err := sc.createConn(sc)
+		if err != nil {
+			return err
+		}
+		sc.conn = conn
+		sc.conn.SetReadLimit(sc.readLimit)
+	}
+
+	sc.setAcknowledged(false)
+	if err := sc.sendConnectionInit(); err != nil {
+		return err
+	}
+
+	return sc.waitForAck()
+}
+
+// waitForAck reads frames directly off the connection until connection_ack
+// arrives, the server reports a connection error, or ackTimeout elapses. It
+// must read the socket itself rather than wait on a channel only Run's read
+// loop fills: Run doesn't start reading until connect returns, so nothing
+// would ever deliver the ack otherwise.
+func (sc *SubscriptionClient) waitForAck() error {
+	type frame struct {
+		message OperationMessage
+		err     error
+	}
+	frameChan := make(chan frame, 1)
+
+	go func() {
+		for {
+			var message OperationMessage
+			if err := sc.conn.ReadJSON(&message); err != nil {
+				frameChan <- frame{err: err}
+				return
+			}
+			if message.Type == GQL_CONNECTION_ACK_WS || message.Type == GQL_CONNECTION_ERROR {
+				frameChan <- frame{message: message}
+				return
+			}
+			// Keep-alive or other pre-ack frames: keep waiting for the ack.
+			sc.printLog(message, GQL_INTERNAL)
+		}
+	}()
+
+	select {
+	case f := <-frameChan:
+		if f.err != nil {
+			_ = sc.conn.Close()
+			sc.conn = nil
+			return f.err
+		}
+		if f.message.Type == GQL_CONNECTION_ERROR {
+			_ = sc.conn.Close()
+			sc.conn = nil
+			return fmt.Errorf("connection_error: %s", f.message.Payload)
+		}
+		sc.onAcknowledged()
+		return nil
+	case <-time.After(sc.ackTimeout):
+		_ = sc.conn.Close()
+		sc.conn = nil
+		return fmt.Errorf("timeout waiting for connection_ack")
+	}
+}
+
+func (sc *SubscriptionClient) printLog(message interface{}, opType OperationMessageType) {
+	if sc.log == nil {
+		return
+	}
+	for _, ty := range sc.disabledLogTypes {
+		if ty == opType {
+			return
+		}
+	}
+
+	sc.log(message)
+}
+
+func (sc *SubscriptionClient) sendConnectionInit() (err error) {
+	var bParams json.RawMessage
+	if sc.connectionParams != nil {
+		bParams, err = json.Marshal(sc.connectionParams)
+		if err != nil {
+			return
+		}
+	}
+
+	msg := sc.protocol.connectionInit(bParams)
+	sc.printLog(msg, GQL_INTERNAL)
+	return sc.conn.WriteJSON(msg)
+}
+
+// Subscribe derives a subscription query from v the same way Query derives a
+// query, registers handler to be called for every data frame delivered for
+// it, until Unsubscribe, and sends a subscribe frame to the server: at once
+// if the client is running, otherwise when Run connects. v is also
+// used to validate each message: it's decoded into a fresh instance of v's
+// type with the same jsonutil.UnmarshalGraphQL logic Client.do uses, and a
+// shape mismatch is reported to handler as an error instead of being passed
+// through silently. The handler callback itself still receives the raw
+// message data, not the decoded value, matching SubscribeRaw. If the call
+// returns an error, the onError event will be triggered.
+func (sc *SubscriptionClient) Subscribe(v interface{}, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error, options ...Option) (*Subscription, error) {
+	query, hash, err := queryAndHash(subscriptionOperation, v, variables, options)
+	if err != nil {
+		return nil, err
+	}
+	return sc.doRaw(query, hash, reflect.TypeOf(v), variables, handler)
+}
+
+// SubscribeRaw is Subscribe with a raw query: handler is called with the raw
+// data of every frame delivered for it; no channel is opened.
+func (sc *SubscriptionClient) SubscribeRaw(query string, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error) (*Subscription, error) {
+	return sc.doRaw(query, sha256.Sum256([]byte(query)), nil, variables, handler)
+}
+
+func (sc *SubscriptionClient) doRaw(query string, hash [sha256.Size]byte, resultType reflect.Type, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error) (*Subscription, error) {
+	id := uuid.New().String()
+
+	sub := subscriptionState{
+		query:          query,
+		variables:      flattenVariables(variables),
+		handler:        sc.wrapHandler(handler),
+		resultType:     resultType,
+		hash:           hash,
+		persistedQuery: sc.persistedQueries,
+	}
+
+	// Register the subscription before sending the subscribe frame: the
+	// server's first reply can reach Run's read loop before this function
+	// would otherwise return, and dispatch silently drops data for an id it
+	// doesn't recognize yet.
+	sc.subscribersMu.Lock()
+	sc.subscriptions[id] = &sub
+	sc.subscribersMu.Unlock()
+
+	// if the websocket client is running, start subscription immediately
+	if atomic.LoadInt64(&sc.isRunning) > 0 {
+		if err := sc.startSubscription(id, &sub); err != nil {
+			sc.subscribersMu.Lock()
+			delete(sc.subscriptions, id)
+			sc.subscribersMu.Unlock()
+			return nil, err
+		}
+	}
+
+	return &Subscription{id: id, client: sc}, nil
+}
+
+// startSubscription sends the subscribe frame for sub if it hasn't been sent yet.
+// When sub.persistedQuery is set, the first attempt sends only sub.hash as a
+// "persistedQuery" extension and omits the query body; dispatch retries with
+// the full query once the server reports the hash as unrecognized.
+func (sc *SubscriptionClient) startSubscription(id string, sub *subscriptionState) error {
+	if sub == nil || sub.started {
+		return nil
+	}
+
+	var extensions map[string]interface{}
+	query := sub.query
+	if sub.persistedQuery {
+		extensions = map[string]interface{}{
+			"persistedQuery": map[string]interface{}{
+				"version":    1,
+				"sha256Hash": hex.EncodeToString(sub.hash[:]),
+			},
+		}
+		if !sub.fullQuerySent {
+			query = ""
+		}
+	}
+
+	msg, err := sc.protocol.subscribe(id, query, sub.variables, extensions)
+	if err != nil {
+		return err
+	}
+
+	sc.printLog(msg, GQL_INTERNAL)
+	if err := sc.conn.WriteJSON(msg); err != nil {
+		return err
+	}
+
+	sub.started = true
+	return nil
+}
+
+func (sc *SubscriptionClient) wrapHandler(fn handlerFunc) func(data *json.RawMessage, err error) {
+	return func(data *json.RawMessage, err error) {
+		if errValue := fn(data, err); errValue != nil {
+			sc.errorChan <- errValue
+		}
+	}
+}
+
+// dispatch delivers data or an error to the handler registered for id, if any.
+// A PersistedQueryNotFound error on a subscription whose full query hasn't
+// been sent yet is swallowed here and triggers a resend with the full query,
+// instead of reaching the caller's handler.
+func (sc *SubscriptionClient) dispatch(id string, data *json.RawMessage, err error) {
+	sc.subscribersMu.Lock()
+	sub, ok := sc.subscriptions[id]
+	sc.subscribersMu.Unlock()
+	if !ok {
+		return
+	}
+
+	if sub.persistedQuery && !sub.fullQuerySent && isPersistedQueryNotFound(err) {
+		sub.fullQuerySent = true
+		sub.started = false
+		if err := sc.startSubscription(id, sub); err != nil {
+			go sub.handler(nil, err)
+		}
+		return
+	}
+
+	if err == nil && data != nil && sub.resultType != nil {
+		if decodeErr := jsonutil.UnmarshalGraphQL(*data, reflect.New(sub.resultType.Elem()).Interface()); decodeErr != nil {
+			go sub.handler(nil, decodeErr)
+			return
+		}
+	}
+
+	go sub.handler(data, err)
+}
+
+// onAcknowledged is called by a protocol implementation once connection_ack is received.
+func (sc *SubscriptionClient) onAcknowledged() {
+	sc.setAcknowledged(true)
+}
+
+// Run starts the websocket client and subscriptions. If run with a goroutine, it can be
+// stopped by calling Close. A transport drop reconnects in place, via this
+// same loop, rather than by recursing into Reset/Run: a long-lived client
+// that reconnects many times over its life must not grow its call stack
+// with every reconnect.
+func (sc *SubscriptionClient) Run() error {
+	for {
+		if err := sc.init(); err != nil {
+			return fmt.Errorf("retry timeout. exiting...")
+		}
+
+		// lazily start subscriptions
+		sc.subscribersMu.Lock()
+		for k, v := range sc.subscriptions {
+			if err := sc.startSubscription(k, v); err != nil {
+				sc.subscribersMu.Unlock()
+				_ = sc.Unsubscribe(k)
+				return err
+			}
+		}
+		sc.subscribersMu.Unlock()
+
+		sc.setIsRunning(true)
+		if sc.onConnected != nil {
+			sc.onConnected()
+		}
+
+		reconnect, err := sc.readLoop()
+		if err != nil {
+			return err
+		}
+		if !reconnect {
+			return nil
+		}
+
+		sc.teardown()
+	}
+}
+
+// readLoop reads frames until the connection drops, the context is
+// canceled, or Close clears isRunning. The returned bool reports whether
+// Run should tear down and reconnect.
+func (sc *SubscriptionClient) readLoop() (bool, error) {
+	for atomic.LoadInt64(&sc.isRunning) > 0 {
+		select {
+		case <-sc.context.Done():
+			return false, nil
+		case e := <-sc.errorChan:
+			if sc.onError != nil {
+				if err := sc.onError(sc, e); err != nil {
+					return false, err
+				}
+			}
+		default:
+			var message OperationMessage
+			if err := sc.conn.ReadJSON(&message); err != nil {
+				// manual EOF check
+				if err == io.EOF || strings.Contains(err.Error(), "EOF") {
+					return true, nil
+				}
+				closeStatus := websocket.CloseStatus(err)
+				if closeStatus == websocket.StatusNormalClosure {
+					// close event from websocket client, exiting...
+					return false, nil
+				}
+				if closeStatus != -1 {
+					sc.printLog(fmt.Sprintf("%s. Retry connecting...", err), GQL_INTERNAL)
+					return true, nil
+				}
+
+				if sc.onError != nil {
+					if err = sc.onError(sc, err); err != nil {
+						return false, err
+					}
+				}
+				continue
+			}
+
+			sc.protocol.onMessage(sc, message)
+		}
+	}
+
+	// if the running status is false, stop retrying
+	return false, nil
+}
+
+// Unsubscribe sends a stop/complete frame to the server and removes the
+// subscription, so its handler is no longer called.
+// The input parameter is the subscription ID that is returned from Subscribe.
+func (sc *SubscriptionClient) Unsubscribe(id string) error {
+	sc.subscribersMu.Lock()
+	defer sc.subscribersMu.Unlock()
+
+	_, ok := sc.subscriptions[id]
+	if !ok {
+		return fmt.Errorf("subscription id %s doesn't not exist", id)
+	}
+
+	delete(sc.subscriptions, id)
+	return sc.stopSubscription(id)
+}
+
+func (sc *SubscriptionClient) stopSubscription(id string) error {
+	if sc.conn != nil {
+		msg := sc.protocol.unsubscribe(id)
+		sc.printLog(msg, GQL_INTERNAL)
+		if err := sc.conn.WriteJSON(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// teardown stops active subscriptions and closes the connection ahead of a
+// reconnect, marking every subscription unstarted so Run's next iteration
+// resends their subscribe frames.
+func (sc *SubscriptionClient) teardown() {
+	sc.subscribersMu.Lock()
+	for id, sub := range sc.subscriptions {
+		_ = sc.stopSubscription(id)
+		sub.started = false
+	}
+	sc.subscribersMu.Unlock()
+
+	if sc.conn != nil {
+		_ = sc.conn.Close()
+		sc.conn = nil
+	}
+	sc.cancel()
+}
+
+// Reset restarts the websocket connection and active subscriptions. Unlike
+// Run's own automatic reconnect on a transport drop, this is meant to be
+// called from outside Run's goroutine, e.g. to force a reconnect after
+// updating connection params.
+func (sc *SubscriptionClient) Reset() error {
+	if atomic.LoadInt64(&sc.isRunning) == 0 {
+		return nil
+	}
+
+	sc.teardown()
+	return sc.Run()
+}
+
+// Close drains outstanding subscriptions, sending a stop/complete frame for each,
+// then closes the websocket.
+func (sc *SubscriptionClient) Close() (err error) {
+	sc.setIsRunning(false)
+
+	sc.subscribersMu.Lock()
+	ids := make([]string, 0, len(sc.subscriptions))
+	for id := range sc.subscriptions {
+		ids = append(ids, id)
+	}
+	sc.subscribersMu.Unlock()
+
+	for _, id := range ids {
+		if err = sc.Unsubscribe(id); err != nil {
+			sc.cancel()
+			return err
+		}
+	}
+
+	if sc.conn != nil {
+		err = sc.conn.Close()
+		sc.conn = nil
+	}
+	sc.cancel()
+
+	return
+}
+
+// WebsocketHandler is the default websocket handler implementation, using
+// https://github.com/nhooyr/websocket
+type WebsocketHandler struct {
+	ctx     context.Context
+	timeout time.Duration
+	*websocket.Conn
+}
+
+func (wh *WebsocketHandler) WriteJSON(v interface{}) error {
+	ctx, cancel := context.WithTimeout(wh.ctx, wh.timeout)
+	defer cancel()
+
+	return wsjson.Write(ctx, wh.Conn, v)
+}
+
+func (wh *WebsocketHandler) ReadJSON(v interface{}) error {
+	ctx, cancel := context.WithTimeout(wh.ctx, wh.timeout)
+	defer cancel()
+	return wsjson.Read(ctx, wh.Conn, v)
+}
+
+func (wh *WebsocketHandler) Close() error {
+	return wh.Conn.Close(websocket.StatusNormalClosure, "close websocket")
+}
+
+func newWebsocketConn(sc *SubscriptionClient) (WebsocketConn, error) {
+	options := &websocket.DialOptions{
+		Subprotocols: []string{sc.protocol.subprotocol()},
+	}
+	c, _, err := websocket.Dial(sc.GetContext(), sc.GetURL(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebsocketHandler{
+		ctx:     sc.GetContext(),
+		Conn:    c,
+		timeout: sc.GetTimeout(),
+	}, nil
+}
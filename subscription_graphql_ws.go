@@ -0,0 +1,120 @@
+package graphql
+
+import (
+	"encoding/json"
+)
+
+// graphqlWS implements the modern graphql-ws protocol, negotiated under the
+// "graphql-transport-ws" WebSocket subprotocol.
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+
+const (
+	// ProtocolGraphQLTransportWS is the subprotocol name of the modern transport.
+	ProtocolGraphQLTransportWS = "graphql-transport-ws"
+
+	// Indicates that the client wants to establish a connection within the existing socket.
+	GQL_CONNECTION_INIT_WS OperationMessageType = "connection_init"
+	// Expected response to connection_init from the server acknowledging a successful connection.
+	GQL_CONNECTION_ACK_WS OperationMessageType = "connection_ack"
+	// May be sent by either client or server at any time within the established socket.
+	GQL_PING OperationMessageType = "ping"
+	// The response to the Ping message. Must be sent as soon as the Ping message is received.
+	GQL_PONG OperationMessageType = "pong"
+	// Requests an operation specified in the message payload.
+	GQL_SUBSCRIBE OperationMessageType = "subscribe"
+	// Operation execution result(s) from the source stream created by the binding subscribe message.
+	GQL_NEXT OperationMessageType = "next"
+	// Operation execution error(s), before or during execution.
+	GQL_ERROR_WS OperationMessageType = "error"
+	// Indicates that the requested operation execution has completed.
+	GQL_COMPLETE_WS OperationMessageType = "complete"
+)
+
+type graphqlWS struct{}
+
+func (graphqlWS) subprotocol() string {
+	return ProtocolGraphQLTransportWS
+}
+
+func (graphqlWS) connectionInit(payload json.RawMessage) OperationMessage {
+	return OperationMessage{
+		Type:    GQL_CONNECTION_INIT_WS,
+		Payload: payload,
+	}
+}
+
+func (graphqlWS) subscribe(id string, query string, variables map[string]interface{}, extensions map[string]interface{}) (OperationMessage, error) {
+	in := struct {
+		Query      string                 `json:"query,omitempty"`
+		Variables  map[string]interface{} `json:"variables,omitempty"`
+		Extensions map[string]interface{} `json:"extensions,omitempty"`
+	}{
+		Query:      query,
+		Variables:  variables,
+		Extensions: extensions,
+	}
+
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return OperationMessage{}, err
+	}
+
+	return OperationMessage{
+		ID:      id,
+		Type:    GQL_SUBSCRIBE,
+		Payload: payload,
+	}, nil
+}
+
+func (graphqlWS) unsubscribe(id string) OperationMessage {
+	return OperationMessage{
+		ID:   id,
+		Type: GQL_COMPLETE_WS,
+	}
+}
+
+func (p graphqlWS) onMessage(sc *SubscriptionClient, message OperationMessage) {
+	switch message.Type {
+	case GQL_ERROR_WS:
+		sc.printLog(message, GQL_ERROR_WS)
+
+		var errs errors
+		if err := json.Unmarshal(message.Payload, &errs); err != nil {
+			sc.dispatch(message.ID, nil, err)
+			return
+		}
+		sc.dispatch(message.ID, nil, errs)
+	case GQL_NEXT:
+		sc.printLog(message, GQL_NEXT)
+
+		var out struct {
+			Data   *json.RawMessage
+			Errors errors
+		}
+		if err := json.Unmarshal(message.Payload, &out); err != nil {
+			sc.dispatch(message.ID, nil, err)
+			return
+		}
+		if len(out.Errors) > 0 {
+			sc.dispatch(message.ID, nil, out.Errors)
+			return
+		}
+		sc.dispatch(message.ID, out.Data, nil)
+	case GQL_COMPLETE_WS:
+		sc.printLog(message, GQL_COMPLETE_WS)
+		_ = sc.Unsubscribe(message.ID)
+	case GQL_PING:
+		sc.printLog(message, GQL_PING)
+		pong := OperationMessage{Type: GQL_PONG, Payload: message.Payload}
+		if sc.conn != nil {
+			_ = sc.conn.WriteJSON(pong)
+		}
+	case GQL_PONG:
+		sc.printLog(message, GQL_PONG)
+	case GQL_CONNECTION_ACK_WS:
+		sc.printLog(message, GQL_CONNECTION_ACK_WS)
+		sc.onAcknowledged()
+	default:
+		sc.printLog(message, GQL_UNKNOWN)
+	}
+}
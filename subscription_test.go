@@ -0,0 +1,208 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWebsocketConn is a WebsocketConn driven entirely from a queue of
+// pre-scripted server frames, for testing SubscriptionClient without a real
+// socket.
+type fakeWebsocketConn struct {
+	mu     sync.Mutex
+	frames []interface{} // OperationMessage or error
+	writes []OperationMessage
+	closed bool
+}
+
+func (c *fakeWebsocketConn) ReadJSON(v interface{}) error {
+	c.mu.Lock()
+	if len(c.frames) == 0 {
+		c.mu.Unlock()
+		return io.EOF
+	}
+	next := c.frames[0]
+	c.frames = c.frames[1:]
+	c.mu.Unlock()
+
+	if err, ok := next.(error); ok {
+		return err
+	}
+	message := next.(OperationMessage)
+	b, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (c *fakeWebsocketConn) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	msg, ok := v.(OperationMessage)
+	if !ok {
+		return fmt.Errorf("unexpected frame type %T", v)
+	}
+	c.writes = append(c.writes, msg)
+	return nil
+}
+
+func (c *fakeWebsocketConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeWebsocketConn) SetReadLimit(limit int64) {}
+
+// TestSubscriptionClientHandshakeAndDispatch drives a full connect/subscribe
+// cycle against a fake connection that acks connection_init and then
+// delivers one data frame, followed by EOF to force a reconnect attempt.
+// It would hang forever before the fix to SubscriptionClient.connect, which
+// waited on a channel only Run's (not-yet-started) read loop could fill.
+func TestSubscriptionClientHandshakeAndDispatch(t *testing.T) {
+	conn := &fakeWebsocketConn{
+		frames: []interface{}{
+			OperationMessage{Type: GQL_CONNECTION_ACK},
+		},
+	}
+
+	attempts := 0
+	sc := NewSubscriptionClient("ws://fake").
+		WithWebSocket(func(sc *SubscriptionClient) (WebsocketConn, error) {
+			attempts++
+			if attempts == 1 {
+				return conn, nil
+			}
+			return nil, fmt.Errorf("dial refused")
+		}).
+		WithAckTimeout(time.Second).
+		WithRetryTimeout(20*time.Millisecond).
+		WithBackoff(time.Millisecond, 5*time.Millisecond)
+
+	received := make(chan *json.RawMessage, 1)
+	sub, err := sc.Subscribe(&struct {
+		Hero struct {
+			Name String
+		} `graphql:"hero"`
+	}{}, nil, func(data *json.RawMessage, err error) error {
+		if err != nil {
+			return nil
+		}
+		received <- data
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if sub.ID() == "" {
+		t.Fatal("expected a subscription id")
+	}
+
+	conn.mu.Lock()
+	conn.frames = append(conn.frames, OperationMessage{
+		ID:      sub.ID(),
+		Type:    GQL_DATA,
+		Payload: json.RawMessage(`{"Data":{"hero":{"Name":"Luke"}}}`),
+	})
+	conn.mu.Unlock()
+
+	runErr := sc.Run()
+	if runErr == nil {
+		t.Fatal("expected Run to return an error once reconnection gives up")
+	}
+
+	select {
+	case <-received:
+	default:
+		t.Fatal("handler never received the data frame delivered before EOF")
+	}
+
+	if attempts < 2 {
+		t.Fatalf("expected Run to retry the connection after EOF, got %d attempt(s)", attempts)
+	}
+}
+
+// TestProtocolSubscribeOmitsEmptyQueryForPersistedQueries ensures the
+// hash-only APQ attempt (query == "") is dropped from the payload instead of
+// being sent as a literal "query":"", matching graphql.go's post(); a strict
+// server would otherwise reject the empty query before ever consulting the
+// persistedQuery extension.
+func TestProtocolSubscribeOmitsEmptyQueryForPersistedQueries(t *testing.T) {
+	extensions := map[string]interface{}{"persistedQuery": apqPersistedQuery{Version: 1}}
+
+	for _, p := range []protocol{subscriptionsTransportWS{}, graphqlWS{}} {
+		msg, err := p.subscribe("1", "", nil, extensions)
+		if err != nil {
+			t.Fatalf("%T.subscribe: %v", p, err)
+		}
+		if string(msg.Payload) == "" || (string(msg.Payload)[0] == '{' && jsonHasQueryField(msg.Payload)) {
+			t.Fatalf("%T: expected an empty query to be omitted from the payload, got %s", p, msg.Payload)
+		}
+	}
+}
+
+func jsonHasQueryField(payload json.RawMessage) bool {
+	var probe struct {
+		Query *string `json:"query"`
+	}
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return false
+	}
+	return probe.Query != nil
+}
+
+// TestSubscribeReportsDataShapeMismatchAgainstV ensures a data frame that
+// doesn't decode into the type passed to Subscribe surfaces as an error to
+// the handler instead of being passed through as if it were valid.
+func TestSubscribeReportsDataShapeMismatchAgainstV(t *testing.T) {
+	conn := &fakeWebsocketConn{
+		frames: []interface{}{
+			OperationMessage{Type: GQL_CONNECTION_ACK},
+		},
+	}
+
+	sc := NewSubscriptionClient("ws://fake").
+		WithWebSocket(func(sc *SubscriptionClient) (WebsocketConn, error) {
+			return conn, nil
+		}).
+		WithAckTimeout(time.Second)
+
+	results := make(chan error, 1)
+	sub, err := sc.Subscribe(&struct {
+		Hero struct {
+			Name String
+		} `graphql:"hero"`
+	}{}, nil, func(data *json.RawMessage, err error) error {
+		results <- err
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	conn.mu.Lock()
+	conn.frames = append(conn.frames, OperationMessage{
+		ID:      sub.ID(),
+		Type:    GQL_DATA,
+		Payload: json.RawMessage(`{"Data":{"hero":"not an object"}}`),
+	})
+	conn.mu.Unlock()
+
+	go sc.Run()
+	defer sc.Close()
+
+	select {
+	case err := <-results:
+		if err == nil {
+			t.Fatal("expected a decode error for data that doesn't match v's shape")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler was never called with the mismatched frame")
+	}
+}
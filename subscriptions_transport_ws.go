@@ -0,0 +1,121 @@
+package graphql
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// subscriptionsTransportWS implements the legacy Apollo subscriptions-transport-ws
+// protocol, historically (and confusingly) negotiated under the "graphql-ws"
+// WebSocket subprotocol.
+// https://github.com/apollographql/subscriptions-transport-ws/blob/master/PROTOCOL.md
+
+const (
+	// ProtocolGraphQLWS is the subprotocol name of the legacy Apollo transport.
+	ProtocolGraphQLWS = "graphql-ws"
+
+	// Client sends this message after plain websocket connection to start the communication with the server
+	GQL_CONNECTION_INIT OperationMessageType = "connection_init"
+	// The server may responses with this message to the GQL_CONNECTION_INIT from client, indicates the server rejected the connection.
+	GQL_CONNECTION_ERROR OperationMessageType = "conn_err"
+	// Client sends this message to execute GraphQL operation
+	GQL_START OperationMessageType = "start"
+	// Client sends this message in order to stop a running GraphQL operation execution (for example: unsubscribe)
+	GQL_STOP OperationMessageType = "stop"
+	// Server sends this message upon a failing operation, before the GraphQL execution, usually due to GraphQL validation errors (resolver errors are part of GQL_DATA message, and will be added as errors array)
+	GQL_ERROR OperationMessageType = "error"
+	// The server sends this message to transfer the GraphQL execution result from the server to the client, this message is a response for GQL_START message.
+	GQL_DATA OperationMessageType = "data"
+	// Server sends this message to indicate that a GraphQL operation is done, and no more data will arrive for the specific operation.
+	GQL_COMPLETE OperationMessageType = "complete"
+	// Server message that should be sent right after each GQL_CONNECTION_ACK processed and then periodically to keep the client connection alive.
+	GQL_CONNECTION_KEEP_ALIVE OperationMessageType = "ka"
+	// The server may responses with this message to the GQL_CONNECTION_INIT from client, indicates the server accepted the connection. May optionally include a payload.
+	GQL_CONNECTION_ACK OperationMessageType = "connection_ack"
+	// Client sends this message to terminate the connection.
+	GQL_CONNECTION_TERMINATE OperationMessageType = "connection_terminate"
+)
+
+type subscriptionsTransportWS struct{}
+
+func (subscriptionsTransportWS) subprotocol() string {
+	return ProtocolGraphQLWS
+}
+
+func (subscriptionsTransportWS) connectionInit(payload json.RawMessage) OperationMessage {
+	return OperationMessage{
+		Type:    GQL_CONNECTION_INIT,
+		Payload: payload,
+	}
+}
+
+func (subscriptionsTransportWS) subscribe(id string, query string, variables map[string]interface{}, extensions map[string]interface{}) (OperationMessage, error) {
+	in := struct {
+		Query      string                 `json:"query,omitempty"`
+		Variables  map[string]interface{} `json:"variables,omitempty"`
+		Extensions map[string]interface{} `json:"extensions,omitempty"`
+	}{
+		Query:      query,
+		Variables:  variables,
+		Extensions: extensions,
+	}
+
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return OperationMessage{}, err
+	}
+
+	return OperationMessage{
+		ID:      id,
+		Type:    GQL_START,
+		Payload: payload,
+	}, nil
+}
+
+func (subscriptionsTransportWS) unsubscribe(id string) OperationMessage {
+	return OperationMessage{
+		ID:   id,
+		Type: GQL_STOP,
+	}
+}
+
+func (p subscriptionsTransportWS) onMessage(sc *SubscriptionClient, message OperationMessage) {
+	switch message.Type {
+	case GQL_ERROR:
+		sc.printLog(message, GQL_ERROR)
+		fallthrough
+	case GQL_DATA:
+		sc.printLog(message, GQL_DATA)
+		id, err := uuid.Parse(message.ID)
+		if err != nil {
+			return
+		}
+
+		var out struct {
+			Data   *json.RawMessage
+			Errors errors
+		}
+		if err := json.Unmarshal(message.Payload, &out); err != nil {
+			sc.dispatch(id.String(), nil, err)
+			return
+		}
+		if len(out.Errors) > 0 {
+			sc.dispatch(id.String(), nil, out.Errors)
+			return
+		}
+		sc.dispatch(id.String(), out.Data, nil)
+	case GQL_CONNECTION_ERROR:
+		sc.printLog(message, GQL_CONNECTION_ERROR)
+	case GQL_COMPLETE:
+		sc.printLog(message, GQL_COMPLETE)
+		_ = sc.Unsubscribe(message.ID)
+	case GQL_CONNECTION_KEEP_ALIVE:
+		sc.printLog(message, GQL_CONNECTION_KEEP_ALIVE)
+	case GQL_CONNECTION_ACK:
+		sc.printLog(message, GQL_CONNECTION_ACK)
+		sc.onAcknowledged()
+	default:
+		sc.printLog(message, GQL_UNKNOWN)
+	}
+}
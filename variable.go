@@ -0,0 +1,85 @@
+package graphql
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+// Variable wraps a query/mutation/subscription variable's value together with
+// a GraphQL default value, so that queryArguments can declare it as
+// $foo:Int!=5 instead of plain $foo:Int!. Value still drives type inference
+// exactly as an unwrapped value would; Default is JSON-encoded and appended
+// after "=". Build one with WithDefault rather than constructing it directly.
+type Variable struct {
+	Value      interface{}
+	Default    interface{}
+	hasDefault bool
+}
+
+// WithDefault wraps value with a GraphQL default, so the variable declaration
+// reads $foo:Int!=5 and the server falls back to defaultValue whenever the
+// request omits "foo" from its variables object. A nil pointer Value combined
+// with a default is the common case for "optional override of a server-side
+// default": the variable is declared as the optional type (writeArgumentType
+// already omits "!" for pointers) and the value sent to the server omits the
+// key entirely, letting the default in the query document apply.
+func WithDefault(value interface{}, defaultValue interface{}) Variable {
+	return Variable{Value: value, Default: defaultValue, hasDefault: true}
+}
+
+// writeArgumentDefault writes "=" followed by the JSON-encoded default value
+// for v, if one was declared via WithDefault.
+func writeArgumentDefault(w io.Writer, v Variable) error {
+	if !v.hasDefault {
+		return nil
+	}
+	b, err := json.Marshal(v.Default)
+	if err != nil {
+		return err
+	}
+	io.WriteString(w, "=")
+	w.Write(b)
+	return nil
+}
+
+// flattenVariables returns the map of variables that should actually be sent
+// to the server: Variable wrappers are replaced by their Value, except that a
+// Variable with a declared default whose Value is a nil pointer is omitted
+// entirely, so the default declared in the query document applies. Returns
+// variables unchanged if it contains no Variable values.
+func flattenVariables(variables map[string]interface{}) map[string]interface{} {
+	hasVariable := false
+	for _, v := range variables {
+		if _, ok := v.(Variable); ok {
+			hasVariable = true
+			break
+		}
+	}
+	if !hasVariable {
+		return variables
+	}
+
+	out := make(map[string]interface{}, len(variables))
+	for k, v := range variables {
+		vr, ok := v.(Variable)
+		if !ok {
+			out[k] = v
+			continue
+		}
+		if vr.hasDefault && isNilValue(vr.Value) {
+			continue
+		}
+		out[k] = vr.Value
+	}
+	return out
+}
+
+// isNilValue reports whether v is a nil interface or a typed nil pointer.
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
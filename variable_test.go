@@ -0,0 +1,69 @@
+package graphql
+
+import "testing"
+
+type zzEpisodeQuery struct {
+	Hero *string `graphql:"hero(episode: $episode)"`
+}
+
+func TestQueryArgumentsRendersDefaultAfterType(t *testing.T) {
+	vars := map[string]interface{}{
+		"episode": WithDefault(String("JEDI"), "JEDI"),
+	}
+	out, err := constructQuery(&zzEpisodeQuery{}, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `query ($episode:String!="JEDI"){hero(episode: $episode)}`
+	if out != want {
+		t.Fatalf("got %q want %q", out, want)
+	}
+}
+
+func TestQueryArgumentsDefaultOnPointerValueIsOptionalType(t *testing.T) {
+	var episode *String
+	vars := map[string]interface{}{
+		"episode": WithDefault(episode, "JEDI"),
+	}
+	out, err := constructQuery(&zzEpisodeQuery{}, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `query ($episode:String="JEDI"){hero(episode: $episode)}`
+	if out != want {
+		t.Fatalf("got %q want %q", out, want)
+	}
+}
+
+func TestFlattenVariablesOmitsNilDefaultedPointerSoServerDefaultApplies(t *testing.T) {
+	var episode *string
+	flat := flattenVariables(map[string]interface{}{
+		"episode": WithDefault(episode, "JEDI"),
+		"other":   5,
+	})
+	if _, ok := flat["episode"]; ok {
+		t.Fatalf("expected a nil-valued default variable to be omitted from the request body, got %v", flat)
+	}
+	if flat["other"] != 5 {
+		t.Fatalf("expected a plain variable to pass through untouched, got %v", flat)
+	}
+}
+
+func TestFlattenVariablesUnwrapsNonNilDefaultedValue(t *testing.T) {
+	name := "JEDI"
+	flat := flattenVariables(map[string]interface{}{
+		"episode": WithDefault(&name, "JEDI"),
+	})
+	got, ok := flat["episode"].(*string)
+	if !ok || got != &name {
+		t.Fatalf("expected the unwrapped pointer value to pass through, got %#v", flat["episode"])
+	}
+}
+
+func TestFlattenVariablesIsNoopWithoutAnyVariableWrapper(t *testing.T) {
+	in := map[string]interface{}{"a": 1, "b": "x"}
+	out := flattenVariables(in)
+	if len(out) != 2 || out["a"] != 1 || out["b"] != "x" {
+		t.Fatalf("expected variables unchanged, got %v", out)
+	}
+}